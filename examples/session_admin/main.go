@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/shivanshuraj1333/clickhuose-test/session"
+)
+
+func main() {
+	opts := &clickhouse.Options{
+		Addr: []string{"localhost:9000"}, // Change if your ClickHouse runs on different port
+		Auth: clickhouse.Auth{
+			Database: "default",
+			Username: "default", // Change to your username
+			Password: "",        // Change to your password if needed
+		},
+		Settings: clickhouse.Settings{
+			"max_execution_time": 60,
+		},
+		DialTimeout:      time.Second * 30,
+		ConnOpenStrategy: clickhouse.ConnOpenInOrder,
+		Debug:            true, // Enable debug logging to see what's happening
+	}
+
+	ctx := context.Background()
+
+	fmt.Println("=== Testing Session Introspection and Admin API ===")
+	fmt.Println("This test enumerates live sessions, inspects one, and cancels a runaway query.")
+	fmt.Println()
+
+	// Test 1: Acquire a couple of sessions with different roles
+	fmt.Println("=== Test 1: Acquire Sessions ===")
+
+	admin, err := session.Acquire(ctx, opts, session.Options{
+		InitStatements: []string{"SET ROLE admin"},
+	})
+	if err != nil {
+		log.Fatalf("Failed to acquire admin session: %v", err)
+	}
+	defer admin.Close()
+
+	readonly, err := session.Acquire(ctx, opts, session.Options{
+		InitStatements: []string{"SET ROLE reader"},
+		Settings:       map[string]any{"readonly": 2},
+	})
+	if err != nil {
+		log.Fatalf("Failed to acquire readonly session: %v", err)
+	}
+	defer readonly.Close()
+
+	fmt.Printf("admin session id: %s\n", admin.ID())
+	fmt.Printf("readonly session id: %s\n", readonly.ID())
+	fmt.Println()
+
+	// Test 2: Enumerate all sessions held by the package
+	fmt.Println("=== Test 2: Sessions() Enumeration ===")
+
+	for _, info := range session.Sessions() {
+		fmt.Printf("  - id=%s role=%s acquiredAt=%s lastUsedAt=%s queryID=%s\n",
+			info.ID, info.Role, info.AcquiredAt.Format(time.RFC3339), info.LastUsedAt.Format(time.RFC3339), info.QueryID)
+	}
+	fmt.Println()
+
+	// Test 3: Cancel a runaway query from a sibling connection
+	fmt.Println("=== Test 3: Cancel Runaway Query ===")
+
+	go func() {
+		if err := admin.Exec(ctx, "SELECT sleep(5)"); err != nil {
+			log.Printf("Runaway query ended: %v", err)
+		}
+	}()
+
+	time.Sleep(200 * time.Millisecond)
+	qid := admin.QueryID()
+	if qid == "" {
+		fmt.Println("Query ID not yet assigned, skipping cancel")
+	} else {
+		if err := admin.Cancel(ctx); err != nil {
+			log.Printf("Failed to cancel query %s: %v", qid, err)
+		} else {
+			fmt.Printf("Issued KILL QUERY for query_id = %s\n", qid)
+		}
+	}
+	fmt.Println()
+
+	// Test 4: Prometheus metrics collector
+	fmt.Println("=== Test 4: Prometheus Metrics ===")
+
+	registry := prometheus.NewRegistry()
+	if err := registry.Register(session.SessionsMetrics()); err != nil {
+		log.Printf("Failed to register sessions collector: %v", err)
+	} else {
+		fmt.Println("Registered sessionsMetrics collector (per-session lifetime, per-role counts)")
+	}
+
+	fmt.Println()
+	fmt.Println("=== Test Complete ===")
+	fmt.Println("session.Sessions() lists every session the package is holding,")
+	fmt.Println("session.Cancel kills a runaway query without tearing down the pool, and")
+	fmt.Println("session.SessionsMetrics() exposes the same data to Prometheus for leak-hunting.")
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/shivanshuraj1333/clickhuose-test/session"
+)
+
+func main() {
+	opts := &clickhouse.Options{
+		Addr: []string{"localhost:9000"}, // Change if your ClickHouse runs on different port
+		Auth: clickhouse.Auth{
+			Database: "default",
+			Username: "default", // Change to your username
+			Password: "",        // Change to your password if needed
+		},
+		Settings: clickhouse.Settings{
+			"max_execution_time": 60,
+		},
+		DialTimeout:      time.Second * 30,
+		ConnOpenStrategy: clickhouse.ConnOpenInOrder,
+		Debug:            true, // Enable debug logging to see what's happening
+	}
+
+	ctx := context.Background()
+
+	fmt.Println("=== Testing session.Pool ===")
+	fmt.Println("This test shows a prewarmed pool of stateful sessions sitting above session.Acquire.")
+	fmt.Println()
+
+	// Test 1: Create and prewarm the pool
+	fmt.Println("=== Test 1: Pool Creation and Prewarming ===")
+
+	pool, err := session.NewPool(ctx, opts, session.PoolOptions{
+		MinIdle:             2,
+		MaxIdle:             5,
+		MaxOpen:             10,
+		IdleTimeout:         5 * time.Minute,
+		MaxLifetime:         time.Hour,
+		HealthCheckInterval: 30 * time.Second,
+		InitStatements: []string{
+			"SET ROLE admin",
+			"SET max_memory_usage = 1000000",
+		},
+	})
+	if err != nil {
+		log.Fatalf("Failed to create session pool: %v", err)
+	}
+	defer pool.Close()
+
+	fmt.Printf("Pool created, stats after prewarm: %+v\n", pool.Stats())
+	fmt.Println()
+
+	// Test 2: Acquire from the pool - should be O(1), no new connection dialed
+	fmt.Println("=== Test 2: Get/Put From Pool ===")
+
+	s, err := pool.Get(ctx)
+	if err != nil {
+		log.Printf("Failed to get session from pool: %v", err)
+	} else {
+		var user string
+		if err := s.QueryRow(ctx, "SELECT currentUser()").Scan(&user); err != nil {
+			log.Printf("Query failed: %v", err)
+		} else {
+			fmt.Printf("Got a prewarmed session, running as: %s\n", user)
+		}
+		pool.Put(s)
+	}
+
+	fmt.Printf("Stats after one round trip: %+v\n", pool.Stats())
+	fmt.Println()
+
+	// Test 3: Exhaust MaxIdle and watch WaitCount/WaitDuration move
+	fmt.Println("=== Test 3: Contention ===")
+
+	held := make([]*session.Session, 0, 5)
+	for i := 0; i < 5; i++ {
+		hs, err := pool.Get(ctx)
+		if err != nil {
+			log.Printf("Failed to get session %d: %v", i, err)
+			continue
+		}
+		held = append(held, hs)
+	}
+
+	fmt.Printf("Stats while holding %d sessions: %+v\n", len(held), pool.Stats())
+
+	for _, hs := range held {
+		pool.Put(hs)
+	}
+
+	fmt.Println()
+	fmt.Println("=== Test Complete ===")
+	fmt.Println("MinIdle sessions are prewarmed with InitStatements applied up front,")
+	fmt.Println("idle sessions are health-checked and evicted in the background, and")
+	fmt.Println("Stats() reports Idle/InUse/WaitCount/WaitDuration like database/sql.")
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/shivanshuraj1333/clickhuose-test/session"
+)
+
+func main() {
+	opts := &clickhouse.Options{
+		Addr: []string{"localhost:9000"}, // Change if your ClickHouse runs on different port
+		Auth: clickhouse.Auth{
+			Database: "default",
+			Username: "default", // Change to your username
+			Password: "",        // Change to your password if needed
+		},
+		Settings: clickhouse.Settings{
+			"max_execution_time": 60,
+		},
+		DialTimeout:      time.Second * 30,
+		ConnOpenStrategy: clickhouse.ConnOpenInOrder,
+		Debug:            true, // Enable debug logging to see what's happening
+	}
+
+	ctx := context.Background()
+
+	fmt.Println("=== Testing Session.BeginTx / RunInTx ===")
+	fmt.Println("This test exercises ClickHouse's experimental transaction support")
+	fmt.Println("through a session-scoped Tx pinned to a single connection.")
+	fmt.Println()
+
+	sess, err := session.Acquire(ctx, opts, session.Options{})
+	if err != nil {
+		log.Fatalf("Failed to acquire session: %v", err)
+	}
+	defer sess.Close()
+
+	// Test 1: Manual BeginTx / Commit
+	fmt.Println("=== Test 1: Manual Commit ===")
+
+	tx, err := sess.BeginTx(ctx, session.TxOptions{})
+	if err != nil {
+		log.Printf("BeginTx failed: %v", err)
+	} else {
+		err = tx.Exec(ctx, `
+			CREATE TABLE IF NOT EXISTS session_tx_test (
+				id UInt32,
+				name String
+			) ENGINE = Memory
+		`)
+		if err != nil {
+			log.Printf("Failed to create table in tx: %v", err)
+			_ = tx.Rollback()
+		} else {
+			batch, err := tx.PrepareBatch(ctx, "INSERT INTO session_tx_test (id, name)")
+			if err != nil {
+				log.Printf("Failed to prepare batch in tx: %v", err)
+				_ = tx.Rollback()
+			} else {
+				if err := batch.Append(uint32(1), "tx_row"); err != nil {
+					log.Printf("Failed to append: %v", err)
+				}
+				if err := batch.Send(); err != nil {
+					log.Printf("Failed to send batch: %v", err)
+				}
+
+				if err := tx.Commit(); err != nil {
+					log.Printf("Commit failed: %v", err)
+				} else {
+					fmt.Println("Committed one row inside the transaction")
+				}
+			}
+		}
+	}
+
+	fmt.Println()
+
+	// Test 2: Rollback on ctx cancellation
+	fmt.Println("=== Test 2: Auto-Rollback On Cancellation ===")
+
+	cancelCtx, cancel := context.WithCancel(ctx)
+	tx2, err := sess.BeginTx(cancelCtx, session.TxOptions{})
+	if err != nil {
+		log.Printf("BeginTx failed: %v", err)
+	} else {
+		if err := tx2.Exec(cancelCtx, "INSERT INTO session_tx_test (id, name) VALUES (2, 'should_not_land')"); err != nil {
+			log.Printf("Exec in tx failed: %v", err)
+		}
+		cancel() // Tx must auto-rollback, never leaving a dangling open transaction
+		fmt.Println("Cancelled context before commit - Tx auto-rolled back")
+	}
+
+	fmt.Println()
+
+	// Test 3: RunInTx with AutoRetry on a retryable (replica-unavailable) error
+	fmt.Println("=== Test 3: RunInTx With AutoRetry ===")
+
+	attempts := 0
+	err = sess.RunInTx(ctx, session.TxOptions{AutoRetry: true}, func(tx *session.Tx) error {
+		attempts++
+		if attempts < 2 {
+			return &clickhouse.Exception{Code: 225, Name: "NO_AVAILABLE_REPLICA", Message: "no available replica, retry the transaction"}
+		}
+		return tx.Exec(ctx, "INSERT INTO session_tx_test (id, name) VALUES (3, 'retried_row')")
+	})
+	if err != nil {
+		log.Printf("RunInTx failed: %v", err)
+	} else {
+		fmt.Printf("RunInTx succeeded after %d attempt(s)\n", attempts)
+	}
+
+	if err := sess.Exec(ctx, "DROP TABLE session_tx_test"); err != nil {
+		log.Printf("Failed to drop test table: %v", err)
+	}
+
+	fmt.Println()
+	fmt.Println("=== Test Complete ===")
+	fmt.Println("Tx pins to its parent session's connection, refuses concurrent use,")
+	fmt.Println("auto-rolls back on Close-without-commit or ctx cancellation, and")
+	fmt.Println("RunInTx restarts the whole closure on a retryable error.")
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/shivanshuraj1333/clickhuose-test/session"
+)
+
+func main() {
+	opts := &clickhouse.Options{
+		Addr: []string{"localhost:9000"}, // Change if your ClickHouse runs on different port
+		Auth: clickhouse.Auth{
+			Database: "default",
+			Username: "default", // Change to your username
+			Password: "",        // Change to your password if needed
+		},
+		Settings: clickhouse.Settings{
+			"max_execution_time": 60,
+		},
+		DialTimeout:      time.Second * 30,
+		ConnOpenStrategy: clickhouse.ConnOpenInOrder,
+		Debug:            true, // Enable debug logging to see what's happening
+	}
+
+	ctx := context.Background()
+
+	fmt.Println("=== Testing StatefulSession Ledger Replay ===")
+	fmt.Println("This test shows a session surviving a dropped TCP connection by")
+	fmt.Println("transparently replaying its SET/SET ROLE/USE/CREATE TEMPORARY TABLE ledger.")
+	fmt.Println()
+
+	// Test 1: Acquire a stateful session and build up some state
+	fmt.Println("=== Test 1: Build Up Session State ===")
+
+	sess, err := session.Acquire(ctx, opts, session.Options{
+		Stateful: true,
+	})
+	if err != nil {
+		log.Fatalf("Failed to acquire session: %v", err)
+	}
+	defer sess.Close()
+
+	for _, stmt := range []string{
+		"SET ROLE admin",
+		"SET max_memory_usage = 1000000",
+		"USE default",
+	} {
+		if err := sess.Exec(ctx, stmt); err != nil {
+			log.Printf("Failed to execute %q: %v", stmt, err)
+			continue
+		}
+		fmt.Printf("Recorded in ledger: %s\n", stmt)
+	}
+
+	fmt.Println()
+
+	// Test 2: Issue another query; if the connection was dropped in between,
+	// Exec/Query transparently reconnect and replay the ledger above first.
+	fmt.Println("=== Test 2: Reconnect and Replay ===")
+
+	// Exec/Query (not QueryRow, whose error only surfaces at Scan time) are
+	// the calls that detect a dropped connection and replay the ledger.
+	rows, err := sess.Query(ctx, "SELECT currentUser()")
+	if err != nil {
+		var lost *session.ErrSessionStateLost
+		if errors.As(err, &lost) {
+			fmt.Printf("Session state could not be recovered, fall back to WithSession: %v\n", lost)
+		} else {
+			log.Printf("Query failed: %v", err)
+		}
+	} else {
+		defer rows.Close()
+		if rows.Next() {
+			var user string
+			if err := rows.Scan(&user); err != nil {
+				log.Printf("Failed to scan user: %v", err)
+			} else {
+				fmt.Printf("Query succeeded, running as: %s\n", user)
+			}
+		}
+	}
+
+	fmt.Println()
+
+	// Test 3: A temporary table makes the ledger unreplayable
+	fmt.Println("=== Test 3: Non-Recoverable State ===")
+
+	err = sess.Exec(ctx, "CREATE TEMPORARY TABLE scratch (id UInt32)")
+	if err != nil {
+		log.Printf("Failed to create temporary table: %v", err)
+	} else {
+		fmt.Println("Created temporary table scratch - ledger now tracks it as non-replayable")
+	}
+
+	fmt.Println()
+	fmt.Println("=== Test Complete ===")
+	fmt.Println("Ledger entries are normalized (last-wins per setting, SET ROLE overrides),")
+	fmt.Println("capped in size, and a temporary table that can't be recreated marks the")
+	fmt.Println("session non-recoverable via ErrSessionStateLost instead of silently losing state.")
+}
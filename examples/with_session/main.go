@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/shivanshuraj1333/clickhuose-test/session"
+)
+
+func main() {
+	// Options describing how to reach ClickHouse. WithSession opens its own
+	// dedicated connection per attempt from these, so no top-level conn.Open
+	// call is needed here.
+	opts := &clickhouse.Options{
+		Addr: []string{"localhost:9000"}, // Change if your ClickHouse runs on different port
+		Auth: clickhouse.Auth{
+			Database: "default",
+			Username: "default", // Change to your username
+			Password: "",        // Change to your password if needed
+		},
+		Settings: clickhouse.Settings{
+			"max_execution_time": 60,
+		},
+		DialTimeout:      time.Second * 30,
+		ConnOpenStrategy: clickhouse.ConnOpenInOrder,
+		Debug:            true, // Enable debug logging to see what's happening
+	}
+
+	ctx := context.Background()
+
+	fmt.Println("=== Testing session.WithSession ===")
+	fmt.Println("This test shows WithSession replacing the manual Acquire/defer Close/retry boilerplate.")
+	fmt.Println()
+
+	// Test 1: Plain WithSession, no retries needed
+	fmt.Println("=== Test 1: Happy Path ===")
+
+	sessOpts := session.Options{
+		InitStatements: []string{
+			"SET ROLE admin",
+			"SET max_memory_usage = 1000000",
+		},
+		Retry: session.RetryPolicy{
+			MaxAttempts:        5,
+			BaseDelay:          100 * time.Millisecond,
+			MaxDelay:           2 * time.Second,
+			Jitter:             0.2,
+			PerAttemptDeadline: 10 * time.Second,
+		},
+	}
+
+	err := session.WithSession(ctx, opts, sessOpts, func(s *session.Session) error {
+		if err := s.Exec(ctx, "SELECT 1"); err != nil {
+			return err
+		}
+
+		var user string
+		if err := s.QueryRow(ctx, "SELECT currentUser()").Scan(&user); err != nil {
+			return err
+		}
+		fmt.Printf("Running as: %s\n", user)
+		return nil
+	})
+	if err != nil {
+		log.Printf("WithSession failed: %v", err)
+	} else {
+		fmt.Println("WithSession completed without needing a retry")
+	}
+
+	fmt.Println()
+
+	// Test 2: Simulate a retryable failure mid-callback
+	fmt.Println("=== Test 2: Retry On Transient Error ===")
+
+	attempts := 0
+	err = session.WithSession(ctx, opts, sessOpts, func(s *session.Session) error {
+		attempts++
+		fmt.Printf("Attempt %d on session %s\n", attempts, s.ID())
+
+		if attempts < 3 {
+			// Simulate the kind of error WithSession is meant to retry:
+			// the server tore this connection down under load.
+			return &clickhouse.Exception{Code: 210, Name: "NETWORK_ERROR", Message: "connection reset by peer"}
+		}
+
+		// By the time we get here, InitStatements must have been replayed
+		// on the fresh session, so SET ROLE admin / max_memory_usage still hold.
+		return s.Exec(ctx, "SELECT 1")
+	})
+	if err != nil {
+		log.Printf("WithSession gave up after retries: %v", err)
+	} else {
+		fmt.Printf("WithSession succeeded after %d attempt(s), state replayed each time\n", attempts)
+	}
+
+	fmt.Println()
+	fmt.Println("=== Test Complete ===")
+	fmt.Println("WithSession classifies network/timeout/overload errors as retryable,")
+	fmt.Println("acquires a fresh session per attempt, replays InitStatements, and")
+	fmt.Println("always closes the session it acquired - no manual defer needed.")
+}
@@ -0,0 +1,167 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var querySeq int64
+
+func nextQueryID() string {
+	return fmt.Sprintf("qid-%d-%d", time.Now().UnixNano(), atomic.AddInt64(&querySeq, 1))
+}
+
+// parseSetRole reports the role name if stmt is a "SET ROLE <name>"
+// statement.
+func parseSetRole(stmt string) (string, bool) {
+	fields := strings.Fields(stmt)
+	if len(fields) < 3 || !strings.EqualFold(fields[0], "SET") || !strings.EqualFold(fields[1], "ROLE") {
+		return "", false
+	}
+	return strings.TrimSuffix(fields[2], ";"), true
+}
+
+// Info is a point-in-time snapshot of one session, as returned by Sessions().
+type Info struct {
+	ID         string
+	Role       string
+	AcquiredAt time.Time
+	LastUsedAt time.Time
+	QueryID    string
+}
+
+var (
+	registryMu sync.Mutex
+	registry   = map[string]*Session{}
+)
+
+func registerSession(s *Session) {
+	registryMu.Lock()
+	registry[s.id] = s
+	registryMu.Unlock()
+}
+
+func deregisterSession(s *Session) {
+	registryMu.Lock()
+	delete(registry, s.id)
+	registryMu.Unlock()
+}
+
+// Sessions lists every session currently held across Acquire, WithSession,
+// Pool, and SessionSet - an enumeration for debugging leaks of the sticky
+// SET ROLE state a Session carries.
+func Sessions() []Info {
+	registryMu.Lock()
+	snapshot := make([]*Session, 0, len(registry))
+	for _, s := range registry {
+		snapshot = append(snapshot, s)
+	}
+	registryMu.Unlock()
+
+	infos := make([]Info, 0, len(snapshot))
+	for _, s := range snapshot {
+		infos = append(infos, s.info())
+	}
+	return infos
+}
+
+func (s *Session) info() Info {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return Info{
+		ID:         s.id,
+		Role:       s.role,
+		AcquiredAt: s.acquiredAt,
+		LastUsedAt: s.lastUsedAt,
+		QueryID:    s.queryID,
+	}
+}
+
+// QueryID returns the query_id assigned to the most recently started query
+// on this session, or "" if no query has run yet.
+func (s *Session) QueryID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.queryID
+}
+
+// Cancel issues KILL QUERY WHERE query_id = <current query id> on a
+// sibling connection, stopping a runaway query without closing the session
+// itself. It returns nil without contacting the server if no query has run
+// on the session yet; once the latest query has finished, the KILL QUERY it
+// issues matches nothing.
+func (s *Session) Cancel(ctx context.Context) error {
+	qid := s.QueryID()
+	if qid == "" {
+		return nil
+	}
+
+	killer, err := clickhouse.Open(s.dialOpts)
+	if err != nil {
+		return fmt.Errorf("session: cancel: open sibling connection: %w", err)
+	}
+	defer killer.Close()
+
+	return killer.Exec(ctx, "KILL QUERY WHERE query_id = {query_id:String}", clickhouse.Named("query_id", qid))
+}
+
+// sessionsCollector is a Prometheus collector reporting per-session
+// lifetime and per-role counts across every session in the registry.
+type sessionsCollector struct {
+	lifetime *prometheus.Desc
+	byRole   *prometheus.Desc
+}
+
+// SessionsMetrics returns a prometheus.Collector exposing session lifetime
+// and per-role session counts, for operators debugging leaks of sticky
+// SET ROLE sessions.
+func SessionsMetrics() prometheus.Collector {
+	return &sessionsCollector{
+		lifetime: prometheus.NewDesc(
+			"clickhouse_session_lifetime_seconds",
+			"How long each currently-held session has been acquired, in seconds.",
+			[]string{"session_id", "role"}, nil,
+		),
+		byRole: prometheus.NewDesc(
+			"clickhouse_sessions_by_role",
+			"Number of currently-held sessions per role.",
+			[]string{"role"}, nil,
+		),
+	}
+}
+
+func (c *sessionsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.lifetime
+	ch <- c.byRole
+}
+
+func (c *sessionsCollector) Collect(ch chan<- prometheus.Metric) {
+	infos := Sessions()
+	now := time.Now()
+
+	counts := map[string]int{}
+	for _, info := range infos {
+		role := info.Role
+		if role == "" {
+			role = "none"
+		}
+		counts[role]++
+
+		ch <- prometheus.MustNewConstMetric(
+			c.lifetime, prometheus.GaugeValue,
+			now.Sub(info.AcquiredAt).Seconds(),
+			info.ID, role,
+		)
+	}
+
+	for role, count := range counts {
+		ch <- prometheus.MustNewConstMetric(c.byRole, prometheus.GaugeValue, float64(count), role)
+	}
+}
@@ -0,0 +1,138 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+)
+
+const defaultLedgerCap = 256
+
+// ErrSessionStateLost is returned when a Stateful session's ledger cannot
+// be replayed after a reconnect because it contains a CREATE TEMPORARY
+// TABLE that can't be guaranteed to come back identical on a new
+// connection. (A replayed statement that fails returns its own wrapped
+// error instead.) The session is marked non-recoverable from then on; the
+// caller should fall back to WithSession to start over with a fresh one.
+type ErrSessionStateLost struct {
+	Reason string
+}
+
+func (e *ErrSessionStateLost) Error() string {
+	return "session: state lost, cannot replay ledger: " + e.Reason
+}
+
+type ledgerEntry struct {
+	key  string // dedup key ("" means always appended, never deduped)
+	stmt string
+}
+
+// ledger records the statements needed to rebuild a Stateful session's
+// state on a new connection: SET/SET ROLE entries are deduped last-wins by
+// setting name, USE and CREATE TEMPORARY TABLE entries are always appended,
+// and the whole thing is capped at a maximum number of entries.
+type ledger struct {
+	mu           sync.Mutex
+	cap          int
+	entries      []ledgerEntry
+	unreplayable *ErrSessionStateLost
+}
+
+func newLedger(capacity int) *ledger {
+	return &ledger{cap: capacity}
+}
+
+// record appends or merges stmt into the ledger. It must only be called
+// after stmt has already executed successfully. Statements outside the
+// four kinds isLedgerable recognizes (SET, SET ROLE, USE, CREATE TEMPORARY
+// TABLE) are ignored: an INSERT or DDL statement replayed verbatim against
+// a fresh connection could duplicate data or fail outright on state that's
+// no longer there, turning a clean reconnect into a bogus failure.
+func (l *ledger) record(stmt string) {
+	if !isLedgerable(stmt) {
+		return
+	}
+
+	key := ledgerKey(stmt)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if key != "" {
+		for i, e := range l.entries {
+			if e.key == key {
+				l.entries[i] = ledgerEntry{key: key, stmt: stmt}
+				return
+			}
+		}
+	}
+
+	if isTempTableStatement(stmt) {
+		l.unreplayable = &ErrSessionStateLost{Reason: fmt.Sprintf("temporary table statement cannot be safely replayed: %s", stmt)}
+	}
+
+	if len(l.entries) >= l.cap {
+		l.entries = l.entries[1:]
+	}
+	l.entries = append(l.entries, ledgerEntry{key: key, stmt: stmt})
+}
+
+// ledgerKey returns the dedup key for stmt: "role" for SET ROLE, a
+// per-setting key for SET <name> = ..., or "" for statements that should
+// always be appended (USE, CREATE TEMPORARY TABLE, ...).
+func ledgerKey(stmt string) string {
+	fields := strings.Fields(stmt)
+	if len(fields) < 2 || !strings.EqualFold(fields[0], "SET") {
+		return ""
+	}
+	if strings.EqualFold(fields[1], "ROLE") {
+		return "role"
+	}
+	name, _, _ := strings.Cut(fields[1], "=")
+	name = strings.ToLower(name)
+	return "setting:" + name
+}
+
+func isTempTableStatement(stmt string) bool {
+	return strings.HasPrefix(strings.ToUpper(strings.TrimSpace(stmt)), "CREATE TEMPORARY TABLE")
+}
+
+// isLedgerable reports whether stmt is one of the statement kinds the
+// ledger is allowed to record: SET, SET ROLE, USE, or CREATE TEMPORARY
+// TABLE. Everything else (INSERT, ALTER, DROP, ...) is session-scoped
+// activity, not session state, and must not be replayed on reconnect.
+func isLedgerable(stmt string) bool {
+	fields := strings.Fields(stmt)
+	if len(fields) == 0 {
+		return false
+	}
+	if strings.EqualFold(fields[0], "SET") || strings.EqualFold(fields[0], "USE") {
+		return true
+	}
+	return isTempTableStatement(stmt)
+}
+
+// replay re-executes every ledger entry, in order, against conn. It fails
+// fast with ErrSessionStateLost if the ledger was already marked
+// unreplayable by a temporary table entry.
+func (l *ledger) replay(ctx context.Context, conn clickhouse.Conn) error {
+	l.mu.Lock()
+	entries := make([]ledgerEntry, len(l.entries))
+	copy(entries, l.entries)
+	unreplayable := l.unreplayable
+	l.mu.Unlock()
+
+	if unreplayable != nil {
+		return unreplayable
+	}
+
+	for _, e := range entries {
+		if err := conn.Exec(ctx, e.stmt); err != nil {
+			return fmt.Errorf("session: replay %q: %w", e.stmt, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+)
+
+// fakeConn implements clickhouse.Conn, recording every Exec call and
+// failing on the names in failOn. Embedding the nil interface lets it
+// satisfy methods replay/record never call.
+type fakeConn struct {
+	clickhouse.Conn
+	execs  []string
+	failOn map[string]error
+}
+
+func (f *fakeConn) Exec(ctx context.Context, query string, args ...any) error {
+	if err := f.failOn[query]; err != nil {
+		return err
+	}
+	f.execs = append(f.execs, query)
+	return nil
+}
+
+func TestLedgerKey(t *testing.T) {
+	tests := []struct {
+		name string
+		stmt string
+		want string
+	}{
+		{"set role", "SET ROLE admin", "role"},
+		{"set role case insensitive", "set role readonly", "role"},
+		{"set setting", "SET max_threads = 4", "setting:max_threads"},
+		{"use", "USE mydb", ""},
+		{"create temp table", "CREATE TEMPORARY TABLE t (x Int32)", ""},
+		{"insert", "INSERT INTO t VALUES (1)", ""},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ledgerKey(tt.stmt); got != tt.want {
+				t.Errorf("ledgerKey(%q) = %q, want %q", tt.stmt, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsLedgerable(t *testing.T) {
+	tests := []struct {
+		name string
+		stmt string
+		want bool
+	}{
+		{"set role", "SET ROLE admin", true},
+		{"set setting", "SET max_threads = 4", true},
+		{"use", "USE mydb", true},
+		{"create temp table", "CREATE TEMPORARY TABLE t (x Int32)", true},
+		{"insert", "INSERT INTO t VALUES (1)", false},
+		{"alter", "ALTER TABLE t ADD COLUMN y Int32", false},
+		{"drop table", "DROP TABLE t", false},
+		{"empty", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isLedgerable(tt.stmt); got != tt.want {
+				t.Errorf("isLedgerable(%q) = %v, want %v", tt.stmt, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLedgerRecordIgnoresNonLedgerableStatements(t *testing.T) {
+	l := newLedger(defaultLedgerCap)
+	l.record("SET ROLE admin")
+	l.record("INSERT INTO t VALUES (1)")
+	l.record("DROP TABLE t")
+
+	if len(l.entries) != 1 {
+		t.Fatalf("entries = %v, want exactly the SET ROLE entry", l.entries)
+	}
+	if l.entries[0].stmt != "SET ROLE admin" {
+		t.Errorf("entries[0].stmt = %q, want %q", l.entries[0].stmt, "SET ROLE admin")
+	}
+}
+
+func TestLedgerRecordDedupsLastWins(t *testing.T) {
+	l := newLedger(defaultLedgerCap)
+	l.record("SET ROLE admin")
+	l.record("SET max_threads = 4")
+	l.record("SET ROLE readonly")
+	l.record("SET max_threads = 8")
+
+	if len(l.entries) != 2 {
+		t.Fatalf("entries = %v, want 2 deduped entries", l.entries)
+	}
+	byKey := map[string]string{}
+	for _, e := range l.entries {
+		byKey[e.key] = e.stmt
+	}
+	if byKey["role"] != "SET ROLE readonly" {
+		t.Errorf("role entry = %q, want last-wins %q", byKey["role"], "SET ROLE readonly")
+	}
+	if byKey["setting:max_threads"] != "SET max_threads = 8" {
+		t.Errorf("setting entry = %q, want last-wins %q", byKey["setting:max_threads"], "SET max_threads = 8")
+	}
+}
+
+func TestLedgerRecordAppendsUseAndTempTable(t *testing.T) {
+	l := newLedger(defaultLedgerCap)
+	l.record("USE db1")
+	l.record("USE db2")
+	l.record("CREATE TEMPORARY TABLE t (x Int32)")
+
+	if len(l.entries) != 3 {
+		t.Fatalf("entries = %v, want every USE/CREATE TEMPORARY TABLE appended", l.entries)
+	}
+}
+
+func TestLedgerRecordMarksTempTableUnreplayable(t *testing.T) {
+	l := newLedger(defaultLedgerCap)
+	l.record("CREATE TEMPORARY TABLE t (x Int32)")
+
+	if l.unreplayable == nil {
+		t.Fatal("unreplayable = nil, want non-nil after recording a temp table statement")
+	}
+}
+
+func TestLedgerRecordCapsEntries(t *testing.T) {
+	l := newLedger(2)
+	l.record("USE db1")
+	l.record("USE db2")
+	l.record("USE db3")
+
+	if len(l.entries) != 2 {
+		t.Fatalf("entries = %d, want capped at 2", len(l.entries))
+	}
+	if l.entries[0].stmt != "USE db2" || l.entries[1].stmt != "USE db3" {
+		t.Errorf("entries = %v, want the oldest evicted", l.entries)
+	}
+}
+
+func TestLedgerReplayRunsEntriesInOrder(t *testing.T) {
+	l := newLedger(defaultLedgerCap)
+	l.record("SET ROLE admin")
+	l.record("USE db1")
+
+	conn := &fakeConn{}
+	if err := l.replay(context.Background(), conn); err != nil {
+		t.Fatalf("replay() = %v, want nil", err)
+	}
+	want := []string{"SET ROLE admin", "USE db1"}
+	if len(conn.execs) != len(want) || conn.execs[0] != want[0] || conn.execs[1] != want[1] {
+		t.Errorf("execs = %v, want %v", conn.execs, want)
+	}
+}
+
+func TestLedgerReplayFailsFastWhenUnreplayable(t *testing.T) {
+	l := newLedger(defaultLedgerCap)
+	l.record("CREATE TEMPORARY TABLE t (x Int32)")
+
+	conn := &fakeConn{}
+	err := l.replay(context.Background(), conn)
+	var lost *ErrSessionStateLost
+	if !errors.As(err, &lost) {
+		t.Fatalf("replay() = %v, want *ErrSessionStateLost", err)
+	}
+	if len(conn.execs) != 0 {
+		t.Errorf("execs = %v, want no statements replayed once marked unreplayable", conn.execs)
+	}
+}
+
+func TestLedgerReplayWrapsExecError(t *testing.T) {
+	l := newLedger(defaultLedgerCap)
+	l.record("USE db1")
+
+	conn := &fakeConn{failOn: map[string]error{"USE db1": errors.New("boom")}}
+	err := l.replay(context.Background(), conn)
+	if err == nil {
+		t.Fatal("replay() = nil, want error when Exec fails")
+	}
+}
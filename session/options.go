@@ -0,0 +1,46 @@
+package session
+
+import "time"
+
+// Options configures a Session returned by Acquire or WithSession.
+type Options struct {
+	// InitStatements are SET/SET ROLE/USE statements executed once right
+	// after the underlying connection is established, and replayed again
+	// on every reconnect (WithSession retry, or StatefulSession replay).
+	InitStatements []string
+
+	// Settings are merged into the dedicated connection's Settings on top
+	// of whatever the parent Options already carry.
+	Settings map[string]any
+
+	// Stateful enables the in-memory statement ledger described in
+	// ledger.go: SET/SET ROLE/USE/CREATE TEMPORARY TABLE statements are
+	// recorded and replayed transparently if the connection drops.
+	Stateful bool
+
+	// Retry controls WithSession's retry behaviour. The zero value means a
+	// single attempt, no retries.
+	Retry RetryPolicy
+}
+
+// RetryPolicy configures WithSession's exponential backoff.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	// <= 1 disables retrying.
+	MaxAttempts int
+
+	// BaseDelay is the delay before the second attempt; it doubles on each
+	// subsequent attempt, capped at MaxDelay.
+	BaseDelay time.Duration
+
+	// MaxDelay caps the backoff delay. Zero means uncapped.
+	MaxDelay time.Duration
+
+	// Jitter is a fraction in [0,1] of random variance applied to each
+	// delay, e.g. 0.2 means +/-20%.
+	Jitter float64
+
+	// PerAttemptDeadline, if non-zero, bounds each attempt (Acquire plus
+	// the callback) with its own context deadline.
+	PerAttemptDeadline time.Duration
+}
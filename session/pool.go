@@ -0,0 +1,290 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+)
+
+// ErrPoolExhausted is returned by Pool.Get when MaxOpen sessions are
+// already in use and no idle session is available.
+var ErrPoolExhausted = errors.New("session: pool exhausted")
+
+// PoolOptions configures a Pool.
+type PoolOptions struct {
+	// MinIdle sessions are opened and their InitStatements run up front, so
+	// Get is O(1) on the hot path instead of dialing + replaying state.
+	MinIdle int
+
+	// MaxIdle caps how many idle sessions Put keeps around; the rest are
+	// closed outright.
+	MaxIdle int
+
+	// MaxOpen caps the total number of sessions (idle + in use). Zero means
+	// unbounded.
+	MaxOpen int
+
+	// IdleTimeout closes an idle session that has sat unused longer than
+	// this. Zero disables the check.
+	IdleTimeout time.Duration
+
+	// MaxLifetime closes a session (idle or not, checked at Get/Put time
+	// and by the health-check loop) once it has existed longer than this.
+	// Zero disables the check.
+	MaxLifetime time.Duration
+
+	// HealthCheckInterval, if non-zero, runs a background goroutine that
+	// pings idle sessions and evicts dead or expired ones.
+	HealthCheckInterval time.Duration
+
+	// InitStatements run on every session the pool opens, prewarmed or not.
+	InitStatements []string
+
+	// Settings are merged into every session the pool opens.
+	Settings map[string]any
+}
+
+// PoolStats mirrors the shape of database/sql.DBStats for session pools.
+type PoolStats struct {
+	Idle         int
+	InUse        int
+	WaitCount    int64
+	WaitDuration time.Duration
+}
+
+type idleSession struct {
+	s         *Session
+	createdAt time.Time
+	idleSince time.Time
+}
+
+// Pool is a pool of stateful Sessions layered above Acquire: MinIdle
+// sessions are prewarmed (InitStatements already applied) so Get is cheap
+// on the hot path, and a background health check evicts dead or expired
+// idle sessions.
+type Pool struct {
+	baseOpts *clickhouse.Options
+	sessOpts Options
+	opts     PoolOptions
+
+	mu    sync.Mutex
+	idle  []*idleSession
+	inUse int
+
+	waitCount    int64
+	waitDuration time.Duration
+
+	stopHealthCheck chan struct{}
+	stopped         bool
+}
+
+// NewPool opens a Pool and prewarms PoolOptions.MinIdle sessions against
+// baseOpts, running InitStatements on each.
+func NewPool(ctx context.Context, baseOpts *clickhouse.Options, poolOpts PoolOptions) (*Pool, error) {
+	p := &Pool{
+		baseOpts: baseOpts,
+		opts:     poolOpts,
+		sessOpts: Options{
+			InitStatements: poolOpts.InitStatements,
+			Settings:       poolOpts.Settings,
+		},
+		stopHealthCheck: make(chan struct{}),
+	}
+
+	for i := 0; i < poolOpts.MinIdle; i++ {
+		s, err := Acquire(ctx, baseOpts, p.sessOpts)
+		if err != nil {
+			p.Close()
+			return nil, fmt.Errorf("session: prewarm session %d/%d: %w", i+1, poolOpts.MinIdle, err)
+		}
+		now := time.Now()
+		p.idle = append(p.idle, &idleSession{s: s, createdAt: now, idleSince: now})
+	}
+
+	if poolOpts.HealthCheckInterval > 0 {
+		go p.healthCheckLoop()
+	}
+
+	return p, nil
+}
+
+// Get returns an idle session if one is available and unexpired, otherwise
+// opens a new one (failing with ErrPoolExhausted if MaxOpen is already
+// reached).
+func (p *Pool) Get(ctx context.Context) (*Session, error) {
+	start := time.Now()
+
+	for {
+		p.mu.Lock()
+		if len(p.idle) > 0 {
+			is := p.idle[len(p.idle)-1]
+			p.idle = p.idle[:len(p.idle)-1]
+			p.mu.Unlock()
+
+			if p.expired(is) {
+				is.s.Close()
+				continue
+			}
+
+			p.mu.Lock()
+			p.inUse++
+			p.recordWait(start)
+			p.mu.Unlock()
+			return is.s, nil
+		}
+
+		if p.opts.MaxOpen > 0 && p.inUse >= p.opts.MaxOpen {
+			p.mu.Unlock()
+			return nil, ErrPoolExhausted
+		}
+		p.inUse++
+		p.recordWait(start)
+		p.mu.Unlock()
+
+		s, err := Acquire(ctx, p.baseOpts, p.sessOpts)
+		if err != nil {
+			p.mu.Lock()
+			p.inUse--
+			p.mu.Unlock()
+			return nil, err
+		}
+		return s, nil
+	}
+}
+
+// recordWait must be called with p.mu held.
+func (p *Pool) recordWait(start time.Time) {
+	p.waitCount++
+	p.waitDuration += time.Since(start)
+}
+
+// Put returns a session to the pool's idle set, or closes it if the pool is
+// already at MaxIdle or stopped.
+func (p *Pool) Put(s *Session) {
+	is := &idleSession{s: s, createdAt: s.acquiredAt, idleSince: time.Now()}
+
+	p.mu.Lock()
+	p.inUse--
+
+	if p.stopped || len(p.idle) >= p.opts.MaxIdle || p.expired(is) {
+		p.mu.Unlock()
+		s.Close()
+		return
+	}
+
+	p.idle = append(p.idle, is)
+	p.mu.Unlock()
+}
+
+// Stats reports a snapshot of the pool's current usage.
+func (p *Pool) Stats() PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return PoolStats{
+		Idle:         len(p.idle),
+		InUse:        p.inUse,
+		WaitCount:    p.waitCount,
+		WaitDuration: p.waitDuration,
+	}
+}
+
+// Close stops the health-check loop and closes every idle session. Sessions
+// currently checked out are the caller's responsibility to Close or Put.
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	if p.stopped {
+		p.mu.Unlock()
+		return nil
+	}
+	p.stopped = true
+	idle := p.idle
+	p.idle = nil
+	p.mu.Unlock()
+
+	close(p.stopHealthCheck)
+
+	var firstErr error
+	for _, is := range idle {
+		if err := is.s.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// expired reports whether an idle session should be evicted for sitting
+// idle too long or for exceeding its MaxLifetime.
+func (p *Pool) expired(is *idleSession) bool {
+	now := time.Now()
+	if p.opts.IdleTimeout > 0 && now.Sub(is.idleSince) > p.opts.IdleTimeout {
+		return true
+	}
+	if p.opts.MaxLifetime > 0 && now.Sub(is.createdAt) > p.opts.MaxLifetime {
+		return true
+	}
+	return false
+}
+
+func (p *Pool) healthCheckLoop() {
+	ticker := time.NewTicker(p.opts.HealthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stopHealthCheck:
+			return
+		case <-ticker.C:
+			p.checkIdleSessions()
+		}
+	}
+}
+
+func (p *Pool) checkIdleSessions() {
+	p.mu.Lock()
+	candidates := make([]*idleSession, len(p.idle))
+	copy(candidates, p.idle)
+	p.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var dead []*idleSession
+	for _, is := range candidates {
+		if p.expired(is) || is.s.Ping(ctx) != nil {
+			dead = append(dead, is)
+		}
+	}
+	if len(dead) == 0 {
+		return
+	}
+
+	// Only close sessions still idle: a dead candidate that Get handed out
+	// after the snapshot above is no longer the pool's to close.
+	var evicted []*idleSession
+	p.mu.Lock()
+	kept := p.idle[:0]
+	for _, is := range p.idle {
+		drop := false
+		for _, d := range dead {
+			if d == is {
+				drop = true
+				break
+			}
+		}
+		if drop {
+			evicted = append(evicted, is)
+		} else {
+			kept = append(kept, is)
+		}
+	}
+	p.idle = kept
+	p.mu.Unlock()
+
+	for _, is := range evicted {
+		is.s.Close()
+	}
+}
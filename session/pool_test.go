@@ -0,0 +1,62 @@
+package session
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPoolExpired(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name string
+		opts PoolOptions
+		is   idleSession
+		want bool
+	}{
+		{
+			name: "no limits configured",
+			opts: PoolOptions{},
+			is:   idleSession{createdAt: now.Add(-time.Hour), idleSince: now.Add(-time.Hour)},
+			want: false,
+		},
+		{
+			name: "idle under timeout",
+			opts: PoolOptions{IdleTimeout: time.Minute},
+			is:   idleSession{createdAt: now, idleSince: now.Add(-30 * time.Second)},
+			want: false,
+		},
+		{
+			name: "idle past timeout",
+			opts: PoolOptions{IdleTimeout: time.Minute},
+			is:   idleSession{createdAt: now, idleSince: now.Add(-90 * time.Second)},
+			want: true,
+		},
+		{
+			name: "under max lifetime",
+			opts: PoolOptions{MaxLifetime: time.Hour},
+			is:   idleSession{createdAt: now.Add(-30 * time.Minute), idleSince: now},
+			want: false,
+		},
+		{
+			name: "past max lifetime",
+			opts: PoolOptions{MaxLifetime: time.Hour},
+			is:   idleSession{createdAt: now.Add(-2 * time.Hour), idleSince: now},
+			want: true,
+		},
+		{
+			name: "past max lifetime but idle timeout not yet hit",
+			opts: PoolOptions{IdleTimeout: time.Hour, MaxLifetime: time.Minute},
+			is:   idleSession{createdAt: now.Add(-2 * time.Minute), idleSince: now},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Pool{opts: tt.opts}
+			if got := p.expired(&tt.is); got != tt.want {
+				t.Errorf("expired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package session
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+)
+
+// retryableCodes are ClickHouse exception codes worth retrying under a
+// fresh session: network/timeout errors and server-side overload signals
+// that are expected to clear up on their own.
+var retryableCodes = map[int32]bool{
+	32:  true, // ATTEMPT_TO_READ_AFTER_EOF
+	159: true, // TIMEOUT_EXCEEDED
+	202: true, // TOO_MANY_SIMULTANEOUS_QUERIES
+	209: true, // SOCKET_TIMEOUT
+	210: true, // NETWORK_ERROR
+	225: true, // NO_AVAILABLE_REPLICA
+	999: true, // KEEPER_EXCEPTION (seen when a replica loses ZK quorum)
+}
+
+// isRetryable classifies an error returned from a session operation as
+// transient (worth acquiring a fresh session and retrying) or not.
+func isRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var ex *clickhouse.Exception
+	if errors.As(err, &ex) {
+		return retryableCodes[ex.Code]
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	return errors.Is(err, io.EOF)
+}
+
+// backoff computes the delay before the given attempt (1-indexed: attempt 1
+// is the delay before the second try), applying exponential growth, an
+// optional cap, and symmetric jitter.
+func backoff(policy RetryPolicy, attempt int) time.Duration {
+	if policy.BaseDelay <= 0 {
+		return 0
+	}
+
+	d := policy.BaseDelay << uint(attempt-1)
+	if policy.MaxDelay > 0 && d > policy.MaxDelay {
+		d = policy.MaxDelay
+	}
+
+	if policy.Jitter > 0 {
+		variance := (rand.Float64()*2 - 1) * policy.Jitter
+		d = time.Duration(float64(d) * (1 + variance))
+	}
+
+	if d < 0 {
+		d = 0
+	}
+	return d
+}
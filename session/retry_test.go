@@ -0,0 +1,90 @@
+package session
+
+import (
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"retryable exception code", &clickhouse.Exception{Code: 210, Name: "NETWORK_ERROR"}, true},
+		{"another retryable code", &clickhouse.Exception{Code: 159, Name: "TIMEOUT_EXCEEDED"}, true},
+		{"non-retryable exception code", &clickhouse.Exception{Code: 60, Name: "UNKNOWN_TABLE"}, false},
+		{"net.Error", &net.DNSError{IsTimeout: true}, true},
+		{"io.EOF", io.EOF, true},
+		{"wrapped io.EOF", errors.Join(io.EOF), true},
+		{"plain error", errors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryable(tt.err); got != tt.want {
+				t.Errorf("isRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoffZeroBaseDelayIsImmediate(t *testing.T) {
+	d := backoff(RetryPolicy{}, 1)
+	if d != 0 {
+		t.Errorf("backoff with zero BaseDelay = %v, want 0", d)
+	}
+}
+
+func TestBackoffGrowsExponentially(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond}
+
+	d1 := backoff(policy, 1)
+	d2 := backoff(policy, 2)
+	d3 := backoff(policy, 3)
+
+	if d1 != 100*time.Millisecond {
+		t.Errorf("backoff(attempt=1) = %v, want 100ms", d1)
+	}
+	if d2 != 200*time.Millisecond {
+		t.Errorf("backoff(attempt=2) = %v, want 200ms", d2)
+	}
+	if d3 != 400*time.Millisecond {
+		t.Errorf("backoff(attempt=3) = %v, want 400ms", d3)
+	}
+}
+
+func TestBackoffRespectsMaxDelay(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: 250 * time.Millisecond}
+
+	d := backoff(policy, 5)
+	if d != 250*time.Millisecond {
+		t.Errorf("backoff(attempt=5) = %v, want capped at 250ms", d)
+	}
+}
+
+func TestBackoffJitterStaysWithinBounds(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, Jitter: 0.5}
+
+	for i := 0; i < 50; i++ {
+		d := backoff(policy, 1)
+		if d < 50*time.Millisecond || d > 150*time.Millisecond {
+			t.Fatalf("backoff with 50%% jitter = %v, want within [50ms, 150ms]", d)
+		}
+	}
+}
+
+func TestBackoffNeverNegative(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, Jitter: 1}
+
+	for i := 0; i < 50; i++ {
+		if d := backoff(policy, 1); d < 0 {
+			t.Fatalf("backoff = %v, want never negative", d)
+		}
+	}
+}
@@ -0,0 +1,356 @@
+// Package session extends github.com/ClickHouse/clickhouse-go/v2 with
+// stateful, single-connection sessions: SET ROLE/SET settings that persist
+// across calls, pooling, reconnect replay, session-scoped transactions, and
+// session introspection. It wraps clickhouse.Conn rather than forking it:
+// the upstream driver only exposes a connection pool, so a Session gets its
+// own dedicated one-connection pool (MaxOpenConns=1, MaxIdleConns=1) and
+// funnels every call through it.
+package session
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+var sessionSeq int64
+
+func nextSessionID() string {
+	return fmt.Sprintf("sess-%d-%d", time.Now().UnixNano(), atomic.AddInt64(&sessionSeq, 1))
+}
+
+// Session is a stateful, single-connection handle acquired with Acquire or
+// WithSession. SET/SET ROLE/settings applied through it persist across
+// every subsequent call, unlike a bare clickhouse.Conn whose calls may land
+// on different pooled connections.
+type Session struct {
+	id       string
+	dialOpts *clickhouse.Options
+	conn     clickhouse.Conn
+
+	mu            sync.Mutex
+	closed        bool
+	txActive      bool
+	role          string
+	queryID       string
+	acquiredAt    time.Time
+	lastUsedAt    time.Time
+	ledger        *ledger // non-nil when Options.Stateful
+	unrecoverable error
+}
+
+// Acquire opens a dedicated single-connection Session against the server(s)
+// described by baseOpts and applies sessOpts.InitStatements.
+func Acquire(ctx context.Context, baseOpts *clickhouse.Options, sessOpts Options) (*Session, error) {
+	dedicated := dedicate(baseOpts, sessOpts)
+
+	conn, err := clickhouse.Open(dedicated)
+	if err != nil {
+		return nil, fmt.Errorf("session: open: %w", err)
+	}
+	if err := conn.Ping(ctx); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("session: ping: %w", err)
+	}
+
+	now := time.Now()
+	s := &Session{
+		id:         nextSessionID(),
+		dialOpts:   dedicated,
+		conn:       conn,
+		acquiredAt: now,
+		lastUsedAt: now,
+	}
+	if sessOpts.Stateful {
+		s.ledger = newLedger(defaultLedgerCap)
+	}
+
+	for _, stmt := range sessOpts.InitStatements {
+		qctx := clickhouse.Context(ctx, clickhouse.WithQueryID(s.touch(stmt)))
+		if err := s.execLedgered(qctx, stmt); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("session: init statement %q: %w", stmt, err)
+		}
+	}
+
+	registerSession(s)
+	return s, nil
+}
+
+// execLedgered runs stmt on the live connection and, if the session is
+// stateful, records it on the ledger once it succeeds.
+func (s *Session) execLedgered(ctx context.Context, stmt string) error {
+	if err := s.getConn().Exec(ctx, stmt); err != nil {
+		return err
+	}
+	if s.ledger != nil {
+		s.ledger.record(stmt)
+	}
+	return nil
+}
+
+// getConn returns the session's current dedicated connection. It takes
+// s.mu because recoverConnection swaps s.conn out from under a concurrent
+// caller (e.g. one goroutine running Exec while another polls QueryID or
+// calls Cancel).
+func (s *Session) getConn() clickhouse.Conn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conn
+}
+
+// setConn swaps in a newly-dialed connection, returning the old one so the
+// caller can close it outside the lock.
+func (s *Session) setConn(conn clickhouse.Conn) clickhouse.Conn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old := s.conn
+	s.conn = conn
+	return old
+}
+
+// dedicate clones baseOpts into a single-connection configuration, merging
+// sessOpts.Settings on top of whatever settings baseOpts already carries.
+func dedicate(baseOpts *clickhouse.Options, sessOpts Options) *clickhouse.Options {
+	cp := *baseOpts
+	cp.MaxOpenConns = 1
+	cp.MaxIdleConns = 1
+
+	settings := clickhouse.Settings{}
+	for k, v := range baseOpts.Settings {
+		settings[k] = v
+	}
+	for k, v := range sessOpts.Settings {
+		settings[k] = v
+	}
+	cp.Settings = settings
+
+	return &cp
+}
+
+// ID returns the session's unique identifier, stable for its lifetime.
+func (s *Session) ID() string {
+	return s.id
+}
+
+// guardTx rejects a call to Exec/Query/QueryRow/PrepareBatch made directly
+// on the Session while a Tx is open on it: those calls share the Session's
+// single dedicated connection with the Tx, and running one mid-transaction
+// would interleave it into the transaction's statement stream. Callers
+// with an open Tx must go through Tx.Exec/Query/QueryRow/PrepareBatch
+// instead.
+func (s *Session) guardTx() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.txActive {
+		return ErrTxInUse
+	}
+	return nil
+}
+
+// touch records query as the session's latest activity: it stamps
+// lastUsedAt, assigns a new QueryID, and tracks SET ROLE so Sessions() can
+// report the session's current role. It returns the assigned QueryID so
+// the caller can attach it to the context sent to the server with
+// clickhouse.WithQueryID - otherwise QueryID() and Cancel() would report
+// an ID the server never saw and KILL QUERY would kill nothing.
+func (s *Session) touch(query string) string {
+	s.mu.Lock()
+	s.lastUsedAt = time.Now()
+	qid := nextQueryID()
+	s.queryID = qid
+	if role, ok := parseSetRole(query); ok {
+		s.role = role
+	}
+	s.mu.Unlock()
+	return qid
+}
+
+// Exec runs query on the session's dedicated connection. If the session is
+// Stateful and the connection was dropped, Exec transparently dials a new
+// one, replays the recorded ledger, and retries query once (see
+// ledger.go).
+func (s *Session) Exec(ctx context.Context, query string, args ...any) error {
+	if err := s.guardTx(); err != nil {
+		return err
+	}
+	ctx = clickhouse.Context(ctx, clickhouse.WithQueryID(s.touch(query)))
+
+	err := s.getConn().Exec(ctx, query, args...)
+	if err == nil {
+		if s.ledger != nil {
+			s.ledger.record(query)
+		}
+		return nil
+	}
+
+	if recoverErr := s.recoverConnection(ctx, err); recoverErr != nil {
+		return recoverErr
+	}
+	if err := s.getConn().Exec(ctx, query, args...); err != nil {
+		return err
+	}
+	if s.ledger != nil {
+		s.ledger.record(query)
+	}
+	return nil
+}
+
+// Query runs query and returns its rows, with the same reconnect-and-replay
+// behaviour as Exec.
+func (s *Session) Query(ctx context.Context, query string, args ...any) (driver.Rows, error) {
+	if err := s.guardTx(); err != nil {
+		return nil, err
+	}
+	ctx = clickhouse.Context(ctx, clickhouse.WithQueryID(s.touch(query)))
+
+	rows, err := s.getConn().Query(ctx, query, args...)
+	if err == nil {
+		return rows, nil
+	}
+
+	if recoverErr := s.recoverConnection(ctx, err); recoverErr != nil {
+		return nil, recoverErr
+	}
+	return s.getConn().Query(ctx, query, args...)
+}
+
+// recoverConnection decides whether err warrants a reconnect-and-replay: it
+// only applies to Stateful sessions seeing a retryable (connection-level)
+// error, and returns the original err unchanged otherwise so the caller
+// sees it directly. Once a session is marked unrecoverable it always
+// returns that error without attempting further reconnects.
+func (s *Session) recoverConnection(ctx context.Context, err error) error {
+	s.mu.Lock()
+	if s.unrecoverable != nil {
+		s.mu.Unlock()
+		return s.unrecoverable
+	}
+	s.mu.Unlock()
+
+	if s.ledger == nil || !isRetryable(err) {
+		return err
+	}
+
+	conn, dialErr := clickhouse.Open(s.dialOpts)
+	if dialErr != nil {
+		return fmt.Errorf("session: reconnect: %w", dialErr)
+	}
+	if pingErr := conn.Ping(ctx); pingErr != nil {
+		conn.Close()
+		return fmt.Errorf("session: reconnect ping: %w", pingErr)
+	}
+
+	if replayErr := s.ledger.replay(ctx, conn); replayErr != nil {
+		conn.Close()
+		s.mu.Lock()
+		s.unrecoverable = replayErr
+		s.mu.Unlock()
+		return replayErr
+	}
+
+	s.setConn(conn).Close()
+	return nil
+}
+
+// QueryRow runs query and returns a single-row result.
+func (s *Session) QueryRow(ctx context.Context, query string, args ...any) driver.Row {
+	if err := s.guardTx(); err != nil {
+		return errRow{err}
+	}
+	ctx = clickhouse.Context(ctx, clickhouse.WithQueryID(s.touch(query)))
+	return s.getConn().QueryRow(ctx, query, args...)
+}
+
+// errRow is a driver.Row that fails every method with a fixed error, used
+// by QueryRow to surface guardTx's rejection without changing its
+// signature.
+type errRow struct{ err error }
+
+func (r errRow) Err() error                { return r.err }
+func (r errRow) Scan(dest ...any) error    { return r.err }
+func (r errRow) ScanStruct(dest any) error { return r.err }
+
+// Ping checks that the dedicated connection is alive.
+func (s *Session) Ping(ctx context.Context) error {
+	return s.getConn().Ping(ctx)
+}
+
+// PrepareBatch prepares a batch insert on the session's connection.
+func (s *Session) PrepareBatch(ctx context.Context, query string) (driver.Batch, error) {
+	if err := s.guardTx(); err != nil {
+		return nil, err
+	}
+	return s.getConn().PrepareBatch(ctx, query)
+}
+
+// Close releases the session's dedicated connection. Safe to call more than
+// once.
+func (s *Session) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.mu.Unlock()
+
+	deregisterSession(s)
+	return s.getConn().Close()
+}
+
+// WithSession acquires a fresh Session, runs fn, and guarantees the session
+// is closed afterwards. If fn (or acquisition) fails with an error
+// isRetryable classifies as transient, it acquires a brand new session -
+// never reusing a torn-down one - replays sessOpts.InitStatements, and
+// retries according to sessOpts.Retry.
+func WithSession(ctx context.Context, baseOpts *clickhouse.Options, sessOpts Options, fn func(*Session) error) error {
+	policy := sessOpts.Retry
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = 1
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= policy.MaxAttempts; attempt++ {
+		if attempt > 1 {
+			d := backoff(policy, attempt-1)
+			timer := time.NewTimer(d)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			}
+		}
+
+		attemptCtx := ctx
+		var cancel context.CancelFunc
+		if policy.PerAttemptDeadline > 0 {
+			attemptCtx, cancel = context.WithTimeout(ctx, policy.PerAttemptDeadline)
+		}
+
+		lastErr = runAttempt(attemptCtx, baseOpts, sessOpts, fn)
+		if cancel != nil {
+			cancel()
+		}
+
+		if lastErr == nil || !isRetryable(lastErr) {
+			return lastErr
+		}
+	}
+	return lastErr
+}
+
+func runAttempt(ctx context.Context, baseOpts *clickhouse.Options, sessOpts Options, fn func(*Session) error) error {
+	s, err := Acquire(ctx, baseOpts, sessOpts)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	return fn(s)
+}
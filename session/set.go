@@ -0,0 +1,73 @@
+package session
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+)
+
+// SessionSet is a named group of Sessions acquired together for one
+// logical unit of work, so a caller can run role-switched queries
+// concurrently on distinct pinned connections without juggling separate
+// Acquire calls and defer stacks.
+type SessionSet struct {
+	sessions map[string]*Session
+}
+
+// NewSessionSet acquires a Session per entry in named, concurrently. If any
+// acquisition fails, every session acquired so far is closed and the first
+// error is returned.
+func NewSessionSet(ctx context.Context, baseOpts *clickhouse.Options, named map[string]Options) (*SessionSet, error) {
+	type result struct {
+		name string
+		s    *Session
+		err  error
+	}
+
+	results := make(chan result, len(named))
+	for name, opts := range named {
+		name, opts := name, opts
+		go func() {
+			s, err := Acquire(ctx, baseOpts, opts)
+			results <- result{name: name, s: s, err: err}
+		}()
+	}
+
+	set := &SessionSet{sessions: make(map[string]*Session, len(named))}
+	var firstErr error
+	for range named {
+		r := <-results
+		if r.err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("session: acquire %q: %w", r.name, r.err)
+			}
+			continue
+		}
+		set.sessions[r.name] = r.s
+	}
+
+	if firstErr != nil {
+		set.Close()
+		return nil, firstErr
+	}
+	return set, nil
+}
+
+// Get returns the named session, or nil if no session was acquired under
+// that name.
+func (set *SessionSet) Get(name string) *Session {
+	return set.sessions[name]
+}
+
+// Close releases every session in the set, closing all of them even if one
+// Close fails, and returns the first error encountered.
+func (set *SessionSet) Close() error {
+	var firstErr error
+	for _, s := range set.sessions {
+		if err := s.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,182 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// ErrTxInUse is returned by BeginTx when the session already has an open,
+// uncommitted transaction; only one Tx may be open per Session at a time.
+var ErrTxInUse = errors.New("session: a transaction is already open on this session")
+
+// TxOptions configures BeginTx and RunInTx.
+type TxOptions struct {
+	// AutoRetry restarts the whole RunInTx closure - a fresh BeginTx - when
+	// it fails with an error isRetryable accepts: the network, timeout and
+	// overload codes in retryableCodes. Serialization and lock-conflict
+	// exception codes are not among them, so those errors are not retried.
+	AutoRetry bool
+
+	// MaxAttempts is the total number of attempts RunInTx makes, including
+	// the first, when AutoRetry is set. <= 0 defaults to 3.
+	MaxAttempts int
+}
+
+// Tx is a ClickHouse experimental transaction pinned to its parent
+// Session's single connection. It auto-rolls back if its context is
+// cancelled, or on Close without a prior Commit. Its own Exec/Query/
+// QueryRow/PrepareBatch methods serialize on connMu so two goroutines
+// sharing one Tx can't interleave statements on the underlying connection.
+type Tx struct {
+	session    *Session
+	cancel     context.CancelFunc
+	done       chan struct{}
+	finishOnce sync.Once
+	finishErr  error
+
+	connMu sync.Mutex
+}
+
+// BeginTx starts a transaction on s, setting implicit_transaction=0 and
+// throw_on_unsupported_query_inside_transaction=0 as ClickHouse's
+// experimental transaction support requires. It fails with ErrTxInUse if s
+// already has an open Tx.
+func (s *Session) BeginTx(ctx context.Context, opts TxOptions) (*Tx, error) {
+	s.mu.Lock()
+	if s.txActive {
+		s.mu.Unlock()
+		return nil, ErrTxInUse
+	}
+	s.txActive = true
+	s.mu.Unlock()
+
+	for _, stmt := range []string{
+		"SET implicit_transaction = 0",
+		"SET throw_on_unsupported_query_inside_transaction = 0",
+		"BEGIN TRANSACTION",
+	} {
+		if err := s.getConn().Exec(ctx, stmt); err != nil {
+			s.mu.Lock()
+			s.txActive = false
+			s.mu.Unlock()
+			return nil, fmt.Errorf("session: begin transaction: %w", err)
+		}
+	}
+
+	txCtx, cancel := context.WithCancel(ctx)
+	tx := &Tx{session: s, cancel: cancel, done: make(chan struct{})}
+	go tx.watchCancellation(txCtx)
+	return tx, nil
+}
+
+// watchCancellation rolls the transaction back if its context is cancelled
+// before Commit or Rollback runs.
+func (tx *Tx) watchCancellation(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+		_ = tx.Rollback()
+	case <-tx.done:
+	}
+}
+
+// Exec runs query inside the transaction.
+func (tx *Tx) Exec(ctx context.Context, query string, args ...any) error {
+	tx.connMu.Lock()
+	defer tx.connMu.Unlock()
+	return tx.session.getConn().Exec(ctx, query, args...)
+}
+
+// Query runs query inside the transaction and returns its rows.
+func (tx *Tx) Query(ctx context.Context, query string, args ...any) (driver.Rows, error) {
+	tx.connMu.Lock()
+	defer tx.connMu.Unlock()
+	return tx.session.getConn().Query(ctx, query, args...)
+}
+
+// QueryRow runs query inside the transaction and returns a single-row
+// result.
+func (tx *Tx) QueryRow(ctx context.Context, query string, args ...any) driver.Row {
+	tx.connMu.Lock()
+	defer tx.connMu.Unlock()
+	return tx.session.getConn().QueryRow(ctx, query, args...)
+}
+
+// PrepareBatch prepares a batch insert inside the transaction.
+func (tx *Tx) PrepareBatch(ctx context.Context, query string) (driver.Batch, error) {
+	tx.connMu.Lock()
+	defer tx.connMu.Unlock()
+	return tx.session.getConn().PrepareBatch(ctx, query)
+}
+
+// Commit commits the transaction.
+func (tx *Tx) Commit() error {
+	return tx.finish("COMMIT")
+}
+
+// Rollback rolls the transaction back. Safe to call after Commit (no-op).
+func (tx *Tx) Rollback() error {
+	return tx.finish("ROLLBACK")
+}
+
+// Close rolls back the transaction if it hasn't been committed yet.
+func (tx *Tx) Close() error {
+	return tx.Rollback()
+}
+
+func (tx *Tx) finish(stmt string) error {
+	tx.finishOnce.Do(func() {
+		tx.session.mu.Lock()
+		tx.session.txActive = false
+		tx.session.mu.Unlock()
+
+		close(tx.done)
+		tx.cancel()
+
+		tx.finishErr = tx.session.getConn().Exec(context.Background(), stmt)
+	})
+	return tx.finishErr
+}
+
+// RunInTx runs fn inside a Tx on s, committing on success and rolling back
+// on error. With opts.AutoRetry, a retryable error restarts the whole
+// closure (a fresh BeginTx), for at most opts.MaxAttempts attempts in all.
+func (s *Session) RunInTx(ctx context.Context, opts TxOptions, fn func(*Tx) error) error {
+	attempts := opts.MaxAttempts
+	if attempts <= 0 {
+		attempts = 1
+		if opts.AutoRetry {
+			attempts = 3
+		}
+	}
+
+	var lastErr error
+	for i := 0; i < attempts; i++ {
+		tx, err := s.BeginTx(ctx, opts)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(tx); err != nil {
+			_ = tx.Rollback()
+			lastErr = err
+			if opts.AutoRetry && isRetryable(err) {
+				continue
+			}
+			return err
+		}
+
+		if err := tx.Commit(); err != nil {
+			lastErr = err
+			if opts.AutoRetry && isRetryable(err) {
+				continue
+			}
+			return err
+		}
+		return nil
+	}
+	return lastErr
+}